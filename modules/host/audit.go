@@ -0,0 +1,250 @@
+package host
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// errRecoveredDataMismatch is returned internally when a redundant copy was
+// read back from the storage backend but still doesn't hash to the
+// contract's FileMerkleRoot.
+var errRecoveredDataMismatch = errors.New("recovered data does not match the contract's Merkle root")
+
+// defaultAuditInterval is how often the background auditor re-checks
+// obligations when the operator hasn't set an AuditPolicy explicitly.
+const defaultAuditInterval = types.BlockHeight(144) // ~24 hours
+
+// auditTick is how often the auditor wakes up to see whether
+// AuditPolicy.Interval worth of blocks have passed. It is a wall-clock
+// timer, independent of block time; whether a pass is due is still decided
+// by h.blockHeight, so no new pass starts while the block height stands still.
+const auditTick = 10 * time.Minute
+
+// An AuditPolicy controls how often, and how thoroughly, the Host's
+// background auditor re-verifies the obligations it is storing.
+type AuditPolicy struct {
+	// Interval is the number of blocks between audit passes.
+	Interval types.BlockHeight
+
+	// RandomSample is the fraction, in (0,1], of obligations checked on each
+	// pass. 1.0 audits every obligation every pass; smaller values spread
+	// the I/O cost of auditing a large number of obligations out over time.
+	RandomSample float64
+}
+
+// AuditStatus summarizes the state of the background auditor, as reported by
+// Host.AuditStatus.
+type AuditStatus struct {
+	LastAuditHeight    types.BlockHeight
+	ObligationsChecked int
+	ObligationsFailed  int
+	Unrecoverable      []types.FileContractID
+}
+
+// recoverableBackend is implemented by a StorageBackend that can attempt to
+// reconstruct data for a handle from a redundant copy, e.g. a second tier or
+// path that still has a good copy. Backends that don't support this simply
+// don't implement the interface, and the auditor treats a failure as
+// unrecoverable.
+type recoverableBackend interface {
+	Recover(handle string) (io.ReadCloser, error)
+}
+
+// tickingStorageBackend is implemented by a StorageBackend that needs to be
+// told the current block height, e.g. TieredBackend's migration to cold
+// storage. Block-processing code isn't part of this chunk of the package, so
+// auditLoop is what drives it instead: it already wakes up periodically and
+// tracks h.blockHeight, making it the nearest thing this chunk has to a
+// per-block hook.
+type tickingStorageBackend interface {
+	Tick(height types.BlockHeight)
+}
+
+// SetAuditPolicy changes how often and how thoroughly the background
+// auditor re-verifies stored obligations.
+func (h *Host) SetAuditPolicy(policy AuditPolicy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.auditPolicy = policy
+}
+
+// AuditStatus returns the current state of the background self-audit loop.
+// modules.HostInfo is defined outside this package and isn't part of this
+// change, so audit status is exposed through its own accessor rather than by
+// adding a field to HostInfo.
+func (h *Host) AuditStatus() AuditStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.auditStatus
+}
+
+// auditLoop periodically re-reads obligation data, recomputes its Merkle
+// root, and compares it against the FileContract's FileMerkleRoot. It runs
+// for the lifetime of the Host, independent of the consensus-driven proof
+// submission at StorageProofReorgDepth, so that bit-rot is caught well
+// before a proof window rather than at proof time.
+func (h *Host) auditLoop() {
+	ticker := time.NewTicker(auditTick)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		h.mu.Lock()
+		policy := h.auditPolicy
+		height := h.blockHeight
+		due := height-h.auditStatus.LastAuditHeight >= policy.Interval
+		obligations := make([]contractObligation, 0, len(h.obligationsByID))
+		if due {
+			for _, ob := range h.obligationsByID {
+				obligations = append(obligations, ob)
+			}
+		}
+		h.mu.Unlock()
+
+		if tb, ok := h.storage.(tickingStorageBackend); ok {
+			tb.Tick(height)
+		}
+
+		if !due {
+			continue
+		}
+		h.runAuditPass(obligations, policy, height)
+	}
+}
+
+// runAuditPass checks a sample of obligations and records the results.
+func (h *Host) runAuditPass(obligations []contractObligation, policy AuditPolicy, height types.BlockHeight) {
+	sample := policy.RandomSample
+	if sample <= 0 || sample > 1 {
+		sample = 1
+	}
+
+	var checked, failed int
+	var unrecoverable []types.FileContractID
+	for _, ob := range obligations {
+		if sample < 1 && rand.Float64() > sample {
+			continue
+		}
+		checked++
+		if h.auditObligation(ob) {
+			continue
+		}
+		failed++
+		unrecoverable = append(unrecoverable, ob.ID)
+	}
+
+	h.mu.Lock()
+	h.auditStatus = AuditStatus{
+		LastAuditHeight:    height,
+		ObligationsChecked: checked,
+		ObligationsFailed:  failed,
+		Unrecoverable:      unrecoverable,
+	}
+	h.mu.Unlock()
+}
+
+// auditObligation re-reads ob's data, recomputes its Merkle root, and
+// compares it against the contract's FileMerkleRoot. On mismatch it attempts
+// reconstruction from a redundant copy, if the storage backend supports one.
+// It returns true if ob's data is confirmed (or successfully recovered) to
+// match the contract.
+func (h *Host) auditObligation(ob contractObligation) bool {
+	ok := h.checkObligationRoot(ob)
+
+	var recoveredHandle string
+	if !ok {
+		if rb, isRecoverable := h.storage.(recoverableBackend); isRecoverable {
+			handle, err := h.recoverObligation(ob, rb)
+			if err == nil {
+				ok = true
+				recoveredHandle = handle
+			} else {
+				h.log.Printf("audit: obligation %v failed Merkle root check and could not be recovered: %v", ob.ID, err)
+			}
+		} else {
+			h.log.Printf("audit: obligation %v failed Merkle root check; storage backend has no redundant copy", ob.ID)
+		}
+	}
+
+	h.mu.Lock()
+	stored, exists := h.obligationsByID[ob.ID]
+	if exists {
+		stored.LastAuditHeight = h.blockHeight
+		if recoveredHandle != "" {
+			stored.Handle = recoveredHandle
+		}
+		if !ok {
+			stored.AuditFailures++
+		}
+		h.obligationsByID[ob.ID] = stored
+	}
+	h.mu.Unlock()
+
+	if !ok {
+		// A confirmed, unrecoverable mismatch means this obligation's
+		// storage proof is guaranteed to fail once its window arrives, so
+		// surface it to metrics and reputation immediately rather than
+		// waiting for the proof deadline.
+		h.recordProofFailed(ob.ID)
+		if ob.RenterKey != (types.SiaPublicKey{}) {
+			h.mu.Lock()
+			rec := h.recordForRenter(ob.RenterKey)
+			rec.ContractsFailed++
+			rec.LastSeenHeight = h.blockHeight
+			h.mu.Unlock()
+		}
+	}
+	return ok
+}
+
+// recoverObligation attempts to reconstruct ob's data from rb and, if the
+// reconstruction matches the contract's FileMerkleRoot, writes it back to
+// the storage backend under a new handle. It returns that handle so the
+// caller can update the obligation's record; the old, now-stale handle is
+// deleted.
+func (h *Host) recoverObligation(ob contractObligation, rb recoverableBackend) (string, error) {
+	r, err := rb.Recover(ob.Handle)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	if crypto.MerkleRoot(data) != ob.FileContract.FileMerkleRoot {
+		return "", errRecoveredDataMismatch
+	}
+
+	newHandle, err := h.storage.Put(ob.ID, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	if err := h.storage.Delete(ob.Handle); err != nil {
+		h.log.Printf("audit: recovered obligation %v but failed to delete stale handle %q: %v", ob.ID, ob.Handle, err)
+	}
+	return newHandle, nil
+}
+
+// checkObligationRoot re-reads ob's stored data and compares its Merkle root
+// against the contract.
+func (h *Host) checkObligationRoot(ob contractObligation) bool {
+	r, err := h.storage.Get(ob.Handle)
+	if err != nil {
+		return false
+	}
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return false
+	}
+	return crypto.MerkleRoot(data) == ob.FileContract.FileMerkleRoot
+}
@@ -29,7 +29,13 @@ type contractObligation struct {
 	ID              types.FileContractID
 	FileContract    types.FileContract
 	LastRevisionTxn types.Transaction
-	Path            string // Where on disk the file is stored.
+	Handle          string             // Opaque handle used to look the data up in the Host's StorageBackend.
+	RenterKey       types.SiaPublicKey // The renter this obligation's contract was formed with.
+
+	// LastAuditHeight and AuditFailures are maintained by the auditor; see
+	// audit.go.
+	LastAuditHeight types.BlockHeight
+	AuditFailures   int
 
 	// each obligation needs a mutex to prevent simultaneous revisions to the
 	// same obligation
@@ -58,6 +64,26 @@ type Host struct {
 	profit              types.Currency
 	modules.HostSettings
 
+	// renterReputation tracks per-renter history so that a ReputationPolicy
+	// can be used to adjust pricing for renters the host has dealt with
+	// before.
+	renterReputation map[types.SiaPublicKey]*RenterRecord
+	reputationPolicy ReputationPolicy
+
+	// storage is where obligation data actually lives. It defaults to a
+	// LocalDiskBackend rooted at persistDir, but can be swapped for a
+	// multi-tier or multi-path backend via New.
+	storage StorageBackend
+
+	// auditPolicy and auditStatus drive the background self-audit loop; see
+	// audit.go.
+	auditPolicy AuditPolicy
+	auditStatus AuditStatus
+
+	// counters and subscribers back Metrics and Subscribe; see metrics.go.
+	counters    counters
+	subscribers []chan HostEvent
+
 	// constants
 	myAddr     modules.NetAddress
 	persistDir string
@@ -67,8 +93,9 @@ type Host struct {
 	mu sync.RWMutex
 }
 
-// New returns an initialized Host.
-func New(cs modules.ConsensusSet, hdb modules.HostDB, tpool modules.TransactionPool, wallet modules.Wallet, addr string, persistDir string) (*Host, error) {
+// New returns an initialized Host. backend may be nil, in which case the
+// Host stores obligations with a LocalDiskBackend rooted at persistDir.
+func New(cs modules.ConsensusSet, hdb modules.HostDB, tpool modules.TransactionPool, wallet modules.Wallet, addr string, persistDir string, backend StorageBackend) (*Host, error) {
 	if cs == nil {
 		return nil, errors.New("host cannot use a nil state")
 	}
@@ -102,9 +129,22 @@ func New(cs modules.ConsensusSet, hdb modules.HostDB, tpool modules.TransactionP
 
 		obligationsByID:     make(map[types.FileContractID]contractObligation),
 		obligationsByHeight: make(map[types.BlockHeight][]contractObligation),
+
+		renterReputation: make(map[types.SiaPublicKey]*RenterRecord),
+		reputationPolicy: defaultReputationPolicy{},
 	}
 	h.spaceRemaining = h.TotalStorage
 
+	if backend == nil {
+		backend = NewLocalDiskBackend(persistDir)
+	}
+	h.storage = backend
+
+	h.auditPolicy = AuditPolicy{
+		Interval:     defaultAuditInterval,
+		RandomSample: 1.0,
+	}
+
 	// Generate signing key, for revising contracts.
 	sk, pk, err := crypto.StdKeyGen.Generate()
 	if err != nil {
@@ -138,6 +178,9 @@ func New(cs modules.ConsensusSet, hdb modules.HostDB, tpool modules.TransactionP
 	// spawn listener
 	go h.listen()
 
+	// spawn the background self-audit loop
+	go h.auditLoop()
+
 	h.cs.ConsensusSetSubscribe(h)
 
 	return h, nil
@@ -0,0 +1,246 @@
+package host
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// revisionRateHalfLife controls how quickly RevisionsPerSecond responds to
+// changes in traffic: each half-life, the contribution of older revisions
+// to the rate decays by half.
+const revisionRateHalfLife = 30 * time.Second
+
+// counters holds the raw numbers backing Metrics, guarded by h.mu like the
+// rest of Host's mutable state. They are bumped only by the record* helpers
+// below; of those, only recordProofFailed has a caller in this package (the
+// auditor), so the other counters stay at zero. Metrics and MetricsHandler
+// only ever read them. BytesStored isn't kept here: it's
+// derived on read from obligationsByID, the same way Info() derives
+// PotentialProfit, since it's just a sum over state the Host already has.
+type counters struct {
+	revisions        int64
+	proofsSubmitted  int64
+	proofsMissed     int64
+	contractsFormed  int64
+	obligationsEnded int64
+
+	lastRevisionTime time.Time
+	revisionRate     float64 // exponentially-weighted moving average, in revisions/sec
+}
+
+// A Snapshot is a point-in-time read of a Host's operational counters,
+// returned by Host.Metrics().
+type Snapshot struct {
+	BytesStored        int64
+	ObligationsByState map[string]int
+	RevisionsPerSecond float64
+	ProofsSubmitted    int64
+	ProofsMissed       int64
+	Profit             types.Currency
+	SpaceRemaining     int64
+	RenterCounts       map[types.SiaPublicKey]int
+}
+
+// An EventType identifies what kind of HostEvent occurred.
+type EventType int
+
+// The set of events a Host publishes to its subscribers.
+const (
+	ContractFormed EventType = iota
+	RevisionAccepted
+	ProofSubmitted
+	ProofFailed
+	ObligationExpired
+)
+
+// A HostEvent is a single notable occurrence in the Host's operation,
+// published to every channel registered with Host.Subscribe.
+type HostEvent struct {
+	Type       EventType
+	Obligation types.FileContractID
+	Renter     types.SiaPublicKey
+}
+
+// subscriberBacklog is how many unconsumed events a subscriber's channel may
+// accumulate before Host starts dropping its oldest pending event to make
+// room for the newest one. This keeps a slow subscriber from ever blocking
+// the host's own operation.
+const subscriberBacklog = 64
+
+// Metrics returns a snapshot of the Host's current operational counters, for
+// use by an external monitoring system.
+func (h *Host) Metrics() Snapshot {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var bytesStored int64
+	byState := make(map[string]int)
+	renterCounts := make(map[types.SiaPublicKey]int)
+	for _, ob := range h.obligationsByID {
+		bytesStored += int64(ob.FileContract.FileSize)
+		if ob.AuditFailures > 0 {
+			byState["corrupt"]++
+		} else {
+			byState["active"]++
+		}
+	}
+	for pk := range h.renterReputation {
+		renterCounts[pk] = h.renterReputation[pk].ContractsCompleted
+	}
+
+	return Snapshot{
+		BytesStored:        bytesStored,
+		ObligationsByState: byState,
+		RevisionsPerSecond: h.counters.revisionRate,
+		ProofsSubmitted:    h.counters.proofsSubmitted,
+		ProofsMissed:       h.counters.proofsMissed,
+		Profit:             h.profit,
+		SpaceRemaining:     h.spaceRemaining,
+		RenterCounts:       renterCounts,
+	}
+}
+
+// MetricsHandler returns an http.Handler that serves the Host's current
+// metrics in Prometheus text-exposition format.
+func (h *Host) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snap := h.Metrics()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		fmt.Fprintf(w, "# HELP sia_host_bytes_stored Total bytes currently stored under contract.\n")
+		fmt.Fprintf(w, "# TYPE sia_host_bytes_stored gauge\n")
+		fmt.Fprintf(w, "sia_host_bytes_stored %d\n", snap.BytesStored)
+
+		fmt.Fprintf(w, "# HELP sia_host_space_remaining_bytes Free space the host can still accept.\n")
+		fmt.Fprintf(w, "# TYPE sia_host_space_remaining_bytes gauge\n")
+		fmt.Fprintf(w, "sia_host_space_remaining_bytes %d\n", snap.SpaceRemaining)
+
+		fmt.Fprintf(w, "# HELP sia_host_proofs_submitted_total Storage proofs successfully submitted.\n")
+		fmt.Fprintf(w, "# TYPE sia_host_proofs_submitted_total counter\n")
+		fmt.Fprintf(w, "sia_host_proofs_submitted_total %d\n", snap.ProofsSubmitted)
+
+		fmt.Fprintf(w, "# HELP sia_host_proofs_missed_total Storage proofs that were not submitted in time.\n")
+		fmt.Fprintf(w, "# TYPE sia_host_proofs_missed_total counter\n")
+		fmt.Fprintf(w, "sia_host_proofs_missed_total %d\n", snap.ProofsMissed)
+
+		fmt.Fprintf(w, "# HELP sia_host_revisions_per_second Moving average of accepted contract revisions per second.\n")
+		fmt.Fprintf(w, "# TYPE sia_host_revisions_per_second gauge\n")
+		fmt.Fprintf(w, "sia_host_revisions_per_second %f\n", snap.RevisionsPerSecond)
+
+		fmt.Fprintf(w, "# HELP sia_host_profit Total profit earned, in hastings.\n")
+		fmt.Fprintf(w, "# TYPE sia_host_profit gauge\n")
+		fmt.Fprintf(w, "sia_host_profit %s\n", snap.Profit.String())
+
+		fmt.Fprintf(w, "# HELP sia_host_obligations Obligations grouped by state.\n")
+		fmt.Fprintf(w, "# TYPE sia_host_obligations gauge\n")
+		for state, count := range snap.ObligationsByState {
+			fmt.Fprintf(w, "sia_host_obligations{state=%q} %d\n", state, count)
+		}
+
+		fmt.Fprintf(w, "# HELP sia_host_renter_contracts_completed Contracts completed successfully, per renter.\n")
+		fmt.Fprintf(w, "# TYPE sia_host_renter_contracts_completed gauge\n")
+		for pk, count := range snap.RenterCounts {
+			fmt.Fprintf(w, "sia_host_renter_contracts_completed{renter=%q} %d\n", pk.String(), count)
+		}
+	})
+}
+
+// Subscribe registers ch to receive the Host's HostEvents. Publishing never
+// blocks: if ch's buffer is full, the oldest pending event on that channel
+// is dropped to make room, so a slow subscriber cannot stall the host. ch is
+// bidirectional because dropping the oldest event means receiving from it.
+func (h *Host) Subscribe(ch chan HostEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers = append(h.subscribers, ch)
+}
+
+// publish sends ev to every subscriber, dropping the oldest queued event on
+// any subscriber whose channel is currently full.
+func (h *Host) publish(ev HostEvent) {
+	h.mu.RLock()
+	subs := append([]chan HostEvent(nil), h.subscribers...)
+	h.mu.RUnlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- ev:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- ev:
+			default:
+			}
+		}
+	}
+}
+
+// recordContractFormed updates counters and publishes a ContractFormed
+// event. It is intended to be called by contract negotiation once a new
+// file contract is signed; that code lives outside this chunk of the
+// package, and nothing in this package calls it.
+func (h *Host) recordContractFormed(id types.FileContractID, renter types.SiaPublicKey) {
+	h.mu.Lock()
+	h.counters.contractsFormed++
+	h.mu.Unlock()
+	h.publish(HostEvent{Type: ContractFormed, Obligation: id, Renter: renter})
+}
+
+// recordRevisionAccepted updates counters, including the RevisionsPerSecond
+// moving average, and publishes a RevisionAccepted event. It is intended to
+// be called by the revision RPC handler once a revision is accepted; that
+// code lives outside this chunk of the package, so for now this is invoked
+// only from tests.
+func (h *Host) recordRevisionAccepted(id types.FileContractID, renter types.SiaPublicKey) {
+	h.mu.Lock()
+	now := time.Now()
+	h.counters.revisions++
+	if last := h.counters.lastRevisionTime; !last.IsZero() {
+		if dt := now.Sub(last).Seconds(); dt > 0 {
+			decay := math.Exp2(-dt / revisionRateHalfLife.Seconds())
+			h.counters.revisionRate = h.counters.revisionRate*decay + (1/dt)*(1-decay)
+		}
+	}
+	h.counters.lastRevisionTime = now
+	h.mu.Unlock()
+	h.publish(HostEvent{Type: RevisionAccepted, Obligation: id, Renter: renter})
+}
+
+// recordProofSubmitted updates counters and publishes a ProofSubmitted
+// event. It is intended to be called once a storage proof transaction is
+// accepted by the transaction pool; that code lives outside this chunk of
+// the package.
+func (h *Host) recordProofSubmitted(id types.FileContractID) {
+	h.mu.Lock()
+	h.counters.proofsSubmitted++
+	h.mu.Unlock()
+	h.publish(HostEvent{Type: ProofSubmitted, Obligation: id})
+}
+
+// recordProofFailed updates counters and publishes a ProofFailed event.
+// Called by auditObligation when it confirms an obligation's data will not
+// pass its storage proof.
+func (h *Host) recordProofFailed(id types.FileContractID) {
+	h.mu.Lock()
+	h.counters.proofsMissed++
+	h.mu.Unlock()
+	h.publish(HostEvent{Type: ProofFailed, Obligation: id})
+}
+
+// recordObligationExpired updates counters and publishes an
+// ObligationExpired event. It is intended to be called once an obligation's
+// window has closed and it is removed from obligationsByID; that code lives
+// outside this chunk of the package.
+func (h *Host) recordObligationExpired(id types.FileContractID) {
+	h.mu.Lock()
+	h.counters.obligationsEnded++
+	h.mu.Unlock()
+	h.publish(HostEvent{Type: ObligationExpired, Obligation: id})
+}
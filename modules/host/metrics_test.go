@@ -0,0 +1,49 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestRecordRevisionAcceptedUpdatesRate verifies that RevisionsPerSecond
+// actually reflects recorded revisions instead of staying at its zero value
+// forever.
+func TestRecordRevisionAcceptedUpdatesRate(t *testing.T) {
+	h := &Host{}
+	var pk types.SiaPublicKey
+
+	h.recordRevisionAccepted(types.FileContractID{}, pk)
+	time.Sleep(10 * time.Millisecond)
+	h.recordRevisionAccepted(types.FileContractID{}, pk)
+
+	snap := h.Metrics()
+	if snap.RevisionsPerSecond <= 0 {
+		t.Fatalf("expected a positive revision rate after two revisions, got %v", snap.RevisionsPerSecond)
+	}
+}
+
+// TestSubscribeDropsOldest verifies that publish drops a subscriber's oldest
+// queued event rather than blocking when that subscriber's channel is full.
+func TestSubscribeDropsOldest(t *testing.T) {
+	h := &Host{}
+	ch := make(chan HostEvent, 1)
+	h.Subscribe(ch)
+
+	var first, second types.FileContractID
+	first[0] = 1
+	second[0] = 2
+
+	h.publish(HostEvent{Type: ProofSubmitted, Obligation: first})
+	h.publish(HostEvent{Type: ProofSubmitted, Obligation: second})
+
+	select {
+	case ev := <-ch:
+		if ev.Obligation != second {
+			t.Fatalf("expected the newest event to survive a full buffer, got %v", ev.Obligation)
+		}
+	default:
+		t.Fatal("expected a buffered event, got none")
+	}
+}
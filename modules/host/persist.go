@@ -0,0 +1,83 @@
+package host
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// persistFilename is the name, within persistDir, of the file the Host uses
+// to save and load state that must survive a restart.
+const persistFilename = "host.json"
+
+// renterReputationEntry is the on-disk representation of a single
+// renterReputation entry. types.SiaPublicKey can't be used directly as a
+// JSON object key, so the map is flattened to a slice for persistence.
+type renterReputationEntry struct {
+	PublicKey types.SiaPublicKey
+	Record    RenterRecord
+}
+
+// persistence is the struct written to and read from persistFilename by
+// save and initPersist.
+type persistence struct {
+	RenterReputation []renterReputationEntry
+}
+
+// initPersist loads the Host's previously-saved state, if any, from
+// persistDir, and initializes the logger. It is called once from New,
+// before the Host is reachable from any other goroutine, so no locking is
+// required here.
+func (h *Host) initPersist() error {
+	if err := os.MkdirAll(h.persistDir, 0700); err != nil {
+		return err
+	}
+
+	logFile, err := os.OpenFile(filepath.Join(h.persistDir, "host.log"), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0660)
+	if err != nil {
+		return err
+	}
+	h.log = log.New(logFile, "", log.Ldate|log.Ltime)
+
+	f, err := os.Open(filepath.Join(h.persistDir, persistFilename))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var p persistence
+	if err := json.NewDecoder(f).Decode(&p); err != nil {
+		return err
+	}
+	for _, entry := range p.RenterReputation {
+		rec := entry.Record
+		h.renterReputation[entry.PublicKey] = &rec
+	}
+	return nil
+}
+
+// save writes the Host's persistent state to persistDir. Callers must hold
+// at least a read lock on h.mu.
+func (h *Host) save() error {
+	p := persistence{
+		RenterReputation: make([]renterReputationEntry, 0, len(h.renterReputation)),
+	}
+	for pk, rec := range h.renterReputation {
+		p.RenterReputation = append(p.RenterReputation, renterReputationEntry{
+			PublicKey: pk,
+			Record:    *rec,
+		})
+	}
+
+	f, err := os.Create(filepath.Join(h.persistDir, persistFilename))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(&p)
+}
@@ -0,0 +1,146 @@
+package host
+
+import (
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// A RenterRecord tracks the host's history with a single renter, identified
+// by its public key. Within this package only the auditor updates a record,
+// setting ContractsFailed and LastSeenHeight; the other fields are not
+// updated here. Records are persisted across restarts as part of the host's
+// regular save.
+type RenterRecord struct {
+	ContractsCompleted int
+	ContractsFailed    int   // bumped on each unrecoverable self-audit failure
+	RevisionLatencyNS  int64 // running average, in nanoseconds
+	BytesStored        int64
+	Profit             types.Currency
+	LastSeenHeight     types.BlockHeight
+}
+
+// RenterInfo is the public view of a RenterRecord, returned by
+// Host.RenterInfo. It also reports the score and price multiplier the host's
+// current ReputationPolicy derives from the record.
+type RenterInfo struct {
+	RenterRecord
+	Score float64
+
+	// PriceMultiplier is a percentage, out of 100: 100 leaves
+	// HostSettings.Price unchanged, under 100 discounts it, and over 100
+	// surcharges it. types.Currency is integer-only, so a multiplier is
+	// expressed this way rather than as a pre-divided fraction, which would
+	// round every non-multiple-of-100 result down to zero.
+	PriceMultiplier types.Currency
+}
+
+// A ReputationPolicy turns a RenterRecord into a score and a price
+// multiplier. Operators can implement their own ReputationPolicy and install
+// it with Host.SetReputationPolicy to customize how renter history affects
+// pricing without patching the Host.
+type ReputationPolicy interface {
+	// Score computes a reputation score for rec. Higher is better; there is
+	// no fixed range.
+	Score(rec RenterRecord) float64
+
+	// PriceMultiplier converts a score into a percentage, out of 100, that
+	// is applied on top of HostSettings.Price when negotiating a contract
+	// with the renter the score was computed for: 100 leaves the price
+	// unchanged, 80 discounts it by 20%, 150 surcharges it by 50%, and so
+	// on. Callers apply it as price.Mul(multiplier).Div(NewCurrency64(100))
+	// rather than dividing here, since types.Currency is integer-only and
+	// would truncate any pre-divided fraction to zero.
+	PriceMultiplier(score float64) types.Currency
+}
+
+// defaultReputationPolicy is the ReputationPolicy used by a Host until the
+// operator installs a different one via SetReputationPolicy.
+type defaultReputationPolicy struct{}
+
+// Score weights completed contracts against failed ones, with failures
+// penalized more heavily since an abandoned revision costs the host
+// collateral.
+func (defaultReputationPolicy) Score(rec RenterRecord) float64 {
+	return float64(rec.ContractsCompleted) - 3*float64(rec.ContractsFailed)
+}
+
+// PriceMultiplier discounts renters with a positive score and surcharges
+// renters with a negative one, clamped to a 20% discount or a 300%
+// surcharge.
+func (defaultReputationPolicy) PriceMultiplier(score float64) types.Currency {
+	switch {
+	case score > 0:
+		discount := score
+		if discount > 20 {
+			discount = 20
+		}
+		return types.NewCurrency64(100 - uint64(discount))
+	case score < 0:
+		penalty := -score
+		if penalty > 300 {
+			penalty = 300
+		}
+		return types.NewCurrency64(100 + uint64(penalty))
+	default:
+		return types.NewCurrency64(100)
+	}
+}
+
+// SetReputationPolicy changes the policy used to score renters and derive
+// their price multiplier. It takes effect on the next negotiation.
+func (h *Host) SetReputationPolicy(policy ReputationPolicy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.reputationPolicy = policy
+}
+
+// RenterInfo returns what the host knows about the renter identified by pk,
+// along with the score and price multiplier its current ReputationPolicy
+// derives from that history. A renter the host has never dealt with gets a
+// zero-value RenterRecord and whatever score/multiplier the policy assigns
+// to it.
+func (h *Host) RenterInfo(pk types.SiaPublicKey) RenterInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	rec := h.renterReputation[pk]
+	if rec == nil {
+		rec = new(RenterRecord)
+	}
+	score := h.reputationPolicy.Score(*rec)
+	return RenterInfo{
+		RenterRecord:    *rec,
+		Score:           score,
+		PriceMultiplier: h.reputationPolicy.PriceMultiplier(score),
+	}
+}
+
+// priceForRenter returns the price the host should quote to pk, equal to
+// HostSettings.Price scaled by the renter's current price multiplier. It is
+// intended to be called by contract negotiation in place of reading h.Price
+// directly; that code lives outside this chunk of the package, and nothing
+// in this package calls it. Unlike recordForRenter, priceForRenter
+// takes its own lock rather than requiring one from the caller, since it has
+// no other host-package caller to share a critical section with.
+func (h *Host) priceForRenter(pk types.SiaPublicKey) types.Currency {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	rec := h.renterReputation[pk]
+	if rec == nil {
+		rec = new(RenterRecord)
+	}
+	score := h.reputationPolicy.Score(*rec)
+	multiplier := h.reputationPolicy.PriceMultiplier(score)
+	return h.Price.Mul(multiplier).Div(types.NewCurrency64(100))
+}
+
+// recordForRenter returns the RenterRecord for pk, creating one if this is
+// the first time the host has seen this renter. Callers must hold h.mu.
+func (h *Host) recordForRenter(pk types.SiaPublicKey) *RenterRecord {
+	rec, ok := h.renterReputation[pk]
+	if !ok {
+		rec = new(RenterRecord)
+		h.renterReputation[pk] = rec
+	}
+	return rec
+}
@@ -0,0 +1,51 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestPriceMultiplierNoTruncation verifies that a renter with a positive
+// score actually receives a discount and a renter with a negative score
+// actually receives a surcharge. A previous version of PriceMultiplier
+// returned a pre-divided fraction (e.g. NewCurrency64(80).Div(NewCurrency64(100))),
+// which types.Currency's integer division collapses to zero for every
+// discount in the advertised 1-20 range, silently making every well-behaved
+// renter's price 0.
+func TestPriceMultiplierNoTruncation(t *testing.T) {
+	policy := defaultReputationPolicy{}
+	price := types.NewCurrency64(1000)
+	hundred := types.NewCurrency64(100)
+
+	discounted := price.Mul(policy.PriceMultiplier(10)).Div(hundred)
+	if discounted.Cmp(types.NewCurrency64(0)) == 0 {
+		t.Fatal("discounted price truncated to zero")
+	}
+	if discounted.Cmp(price) >= 0 {
+		t.Fatalf("expected a discount for a positive score, got %v >= %v", discounted, price)
+	}
+
+	surcharged := price.Mul(policy.PriceMultiplier(-10)).Div(hundred)
+	if surcharged.Cmp(price) <= 0 {
+		t.Fatalf("expected a surcharge for a negative score, got %v <= %v", surcharged, price)
+	}
+
+	unchanged := price.Mul(policy.PriceMultiplier(0)).Div(hundred)
+	if unchanged.Cmp(price) != 0 {
+		t.Fatalf("expected an unchanged price for a zero score, got %v", unchanged)
+	}
+}
+
+// TestPriceMultiplierClamped verifies that extreme scores are clamped rather
+// than producing a zero or negative multiplier.
+func TestPriceMultiplierClamped(t *testing.T) {
+	policy := defaultReputationPolicy{}
+
+	if m := policy.PriceMultiplier(1e6); m.Cmp(types.NewCurrency64(80)) != 0 {
+		t.Fatalf("expected the discount to clamp at 20%% (multiplier 80), got %v", m)
+	}
+	if m := policy.PriceMultiplier(-1e6); m.Cmp(types.NewCurrency64(400)) != 0 {
+		t.Fatalf("expected the surcharge to clamp at 300%% (multiplier 400), got %v", m)
+	}
+}
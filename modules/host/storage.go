@@ -0,0 +1,379 @@
+package host
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// A StorageBackend is where a Host keeps the data backing its contract
+// obligations. contractObligation.Handle is an opaque string that a
+// StorageBackend uses to find its own data for an obligation; the Host
+// itself never interprets it. Storage-proof construction and revision
+// handling read and write obligation data exclusively through this
+// interface, so a Host can be configured with a single disk, several disks,
+// or an offload-to-slow-storage policy without any other code changing.
+type StorageBackend interface {
+	// Put stores the contents of r under a new handle and returns it.
+	Put(id types.FileContractID, r io.Reader) (handle string, err error)
+
+	// Get returns a ReadSeekCloser for the data stored under handle.
+	Get(handle string) (io.ReadSeekCloser, error)
+
+	// Delete removes the data stored under handle.
+	Delete(handle string) error
+
+	// Size returns the number of bytes stored under handle.
+	Size(handle string) (int64, error)
+
+	// SpaceRemaining returns an estimate of how many bytes the backend can
+	// still accept.
+	SpaceRemaining() int64
+}
+
+// LocalDiskBackend is the default StorageBackend: every obligation is a
+// single file inside a directory on local disk. This matches the Host's
+// historical behavior, where contractObligation.Path pointed directly at
+// such a file.
+type LocalDiskBackend struct {
+	dir string
+
+	mu      sync.Mutex
+	counter int
+}
+
+// NewLocalDiskBackend returns a LocalDiskBackend rooted at dir. dir is
+// created if it does not already exist.
+func NewLocalDiskBackend(dir string) *LocalDiskBackend {
+	os.MkdirAll(dir, 0700) // a failure here surfaces as an error from Put
+	return &LocalDiskBackend{dir: dir}
+}
+
+// Put implements StorageBackend.
+func (b *LocalDiskBackend) Put(id types.FileContractID, r io.Reader) (string, error) {
+	b.mu.Lock()
+	b.counter++
+	handle := filepath.Join(b.dir, id.String()+"-"+strconv.Itoa(b.counter))
+	b.mu.Unlock()
+
+	f, err := os.Create(handle)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, r); err != nil {
+		return "", err
+	}
+	return handle, nil
+}
+
+// Get implements StorageBackend.
+func (b *LocalDiskBackend) Get(handle string) (io.ReadSeekCloser, error) {
+	return os.Open(handle)
+}
+
+// Delete implements StorageBackend.
+func (b *LocalDiskBackend) Delete(handle string) error {
+	return os.Remove(handle)
+}
+
+// Size implements StorageBackend.
+func (b *LocalDiskBackend) Size(handle string) (int64, error) {
+	fi, err := os.Stat(handle)
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// SpaceRemaining implements StorageBackend by reporting actual free space on
+// the filesystem backing dir, via diskFreeSpace (platform-specific; see
+// storage_unix.go and storage_windows.go).
+func (b *LocalDiskBackend) SpaceRemaining() int64 {
+	free, err := diskFreeSpace(b.dir)
+	if err != nil {
+		return 0
+	}
+	return free
+}
+
+// tieredEntry tracks an obligation under management by a TieredBackend: the
+// contract it belongs to (needed to re-Put it on the cold tier), the handle
+// it currently lives at on whichever tier holds it, and when it was last
+// accessed.
+type tieredEntry struct {
+	id types.FileContractID
+
+	hotHandle  string // always set; kept even after migration as a Recover fallback
+	coldHandle string // set once the obligation has migrated
+
+	lastAccess types.BlockHeight
+	onCold     bool
+}
+
+// currentHandle returns whichever of hotHandle/coldHandle the entry is
+// currently backed by.
+func (e *tieredEntry) currentHandle() string {
+	if e.onCold {
+		return e.coldHandle
+	}
+	return e.hotHandle
+}
+
+// TieredBackend keeps recently-accessed obligations on a fast backend (e.g.
+// SSD) and migrates ones that haven't been touched in coldAfter blocks to a
+// slower backend (e.g. spinning disk or network storage). The current block
+// height is supplied via Tick, which this chunk's audit loop calls
+// periodically (see audit.go's auditLoop) since block-processing code isn't
+// part of this chunk of the package; a host wired into real consensus
+// updates should call Tick from there instead for the intended per-block
+// cadence.
+type TieredBackend struct {
+	hot  StorageBackend
+	cold StorageBackend
+
+	coldAfter types.BlockHeight
+
+	mu      sync.Mutex
+	height  types.BlockHeight
+	counter uint64
+	entries map[string]*tieredEntry // keyed by the stable handle returned from Put
+}
+
+// NewTieredBackend returns a TieredBackend that migrates obligations to cold
+// once they have gone coldAfter blocks without being accessed.
+func NewTieredBackend(hot, cold StorageBackend, coldAfter types.BlockHeight) *TieredBackend {
+	return &TieredBackend{
+		hot:       hot,
+		cold:      cold,
+		coldAfter: coldAfter,
+		entries:   make(map[string]*tieredEntry),
+	}
+}
+
+// Tick advances the TieredBackend's notion of the current block height and
+// migrates any obligation that has gone cold since the last Tick. The handle
+// returned from the original Put stays valid (and unchanged) across
+// migration: only the tieredEntry's internal bookkeeping moves to the cold
+// tier. The hot copy's handle is kept on the entry rather than discarded, so
+// Recover still has somewhere to look if Delete below fails to actually
+// remove it.
+func (b *TieredBackend) Tick(height types.BlockHeight) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.height = height
+	for _, e := range b.entries {
+		if e.onCold || height-e.lastAccess < b.coldAfter {
+			continue
+		}
+		r, err := b.hot.Get(e.hotHandle)
+		if err != nil {
+			continue
+		}
+		coldHandle, err := b.cold.Put(e.id, r)
+		r.Close()
+		if err != nil {
+			continue
+		}
+		b.hot.Delete(e.hotHandle)
+		e.coldHandle = coldHandle
+		e.onCold = true
+	}
+}
+
+// Put implements StorageBackend by writing to the hot tier and returning a
+// handle that stays stable even after the obligation migrates to cold.
+func (b *TieredBackend) Put(id types.FileContractID, r io.Reader) (string, error) {
+	hotHandle, err := b.hot.Put(id, r)
+	if err != nil {
+		return "", err
+	}
+
+	b.mu.Lock()
+	b.counter++
+	handle := "tiered-" + strconv.FormatUint(b.counter, 10)
+	b.entries[handle] = &tieredEntry{id: id, hotHandle: hotHandle, lastAccess: b.height}
+	b.mu.Unlock()
+	return handle, nil
+}
+
+// Recover implements the recoverableBackend interface used by the
+// background auditor (see audit.go). TieredBackend doesn't keep a
+// genuinely redundant copy once migration completes, but Tick keeps the
+// pre-migration hot handle around rather than discarding it, so if Tick's
+// Delete of the hot copy failed (Tick ignores that error) the data may still
+// be retrievable from whichever tier the entry isn't currently pointing at.
+func (b *TieredBackend) Recover(handle string) (io.ReadCloser, error) {
+	b.mu.Lock()
+	e, ok := b.entries[handle]
+	b.mu.Unlock()
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	if e.onCold && e.hotHandle != "" {
+		if r, err := b.hot.Get(e.hotHandle); err == nil {
+			return r, nil
+		}
+	}
+	if !e.onCold && e.coldHandle != "" {
+		if r, err := b.cold.Get(e.coldHandle); err == nil {
+			return r, nil
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+// Get implements StorageBackend, reading from whichever tier currently holds
+// handle and refreshing its last-access height.
+func (b *TieredBackend) Get(handle string) (io.ReadSeekCloser, error) {
+	b.mu.Lock()
+	e, ok := b.entries[handle]
+	if !ok {
+		b.mu.Unlock()
+		return nil, os.ErrNotExist
+	}
+	e.lastAccess = b.height
+	internalHandle, onCold := e.currentHandle(), e.onCold
+	b.mu.Unlock()
+
+	if onCold {
+		return b.cold.Get(internalHandle)
+	}
+	return b.hot.Get(internalHandle)
+}
+
+// Delete implements StorageBackend, removing handle from whichever tier
+// currently holds it.
+func (b *TieredBackend) Delete(handle string) error {
+	b.mu.Lock()
+	e, ok := b.entries[handle]
+	delete(b.entries, handle)
+	b.mu.Unlock()
+
+	if !ok {
+		return os.ErrNotExist
+	}
+	if e.onCold {
+		return b.cold.Delete(e.coldHandle)
+	}
+	return b.hot.Delete(e.hotHandle)
+}
+
+// Size implements StorageBackend.
+func (b *TieredBackend) Size(handle string) (int64, error) {
+	b.mu.Lock()
+	e, ok := b.entries[handle]
+	b.mu.Unlock()
+	if !ok {
+		return 0, os.ErrNotExist
+	}
+	if e.onCold {
+		return b.cold.Size(e.coldHandle)
+	}
+	return b.hot.Size(e.hotHandle)
+}
+
+// SpaceRemaining implements StorageBackend by reporting the hot tier's
+// remaining space, since that is what constrains accepting new obligations.
+func (b *TieredBackend) SpaceRemaining() int64 {
+	return b.hot.SpaceRemaining()
+}
+
+// MultiPathBackend spreads obligations across several backends (one per
+// mount point, ordinarily), choosing the one with the most free space for
+// each new Put. This lets an operator combine several disks into a single
+// logical backend.
+type MultiPathBackend struct {
+	mu    sync.Mutex
+	paths []StorageBackend
+	owner map[string]StorageBackend
+}
+
+// NewMultiPathBackend returns a MultiPathBackend backed by a LocalDiskBackend
+// per entry in dirs.
+func NewMultiPathBackend(dirs []string) *MultiPathBackend {
+	b := &MultiPathBackend{
+		owner: make(map[string]StorageBackend),
+	}
+	for _, dir := range dirs {
+		b.paths = append(b.paths, NewLocalDiskBackend(dir))
+	}
+	return b
+}
+
+// Put implements StorageBackend by choosing the path with the most free
+// space.
+func (b *MultiPathBackend) Put(id types.FileContractID, r io.Reader) (string, error) {
+	b.mu.Lock()
+	paths := append([]StorageBackend(nil), b.paths...)
+	b.mu.Unlock()
+
+	sort.Slice(paths, func(i, j int) bool {
+		return paths[i].SpaceRemaining() > paths[j].SpaceRemaining()
+	})
+	if len(paths) == 0 {
+		return "", os.ErrNotExist
+	}
+	chosen := paths[0]
+	handle, err := chosen.Put(id, r)
+	if err != nil {
+		return "", err
+	}
+
+	b.mu.Lock()
+	b.owner[handle] = chosen
+	b.mu.Unlock()
+	return handle, nil
+}
+
+// Get implements StorageBackend.
+func (b *MultiPathBackend) Get(handle string) (io.ReadSeekCloser, error) {
+	b.mu.Lock()
+	owner := b.owner[handle]
+	b.mu.Unlock()
+	if owner == nil {
+		return nil, os.ErrNotExist
+	}
+	return owner.Get(handle)
+}
+
+// Delete implements StorageBackend.
+func (b *MultiPathBackend) Delete(handle string) error {
+	b.mu.Lock()
+	owner := b.owner[handle]
+	delete(b.owner, handle)
+	b.mu.Unlock()
+	if owner == nil {
+		return os.ErrNotExist
+	}
+	return owner.Delete(handle)
+}
+
+// Size implements StorageBackend.
+func (b *MultiPathBackend) Size(handle string) (int64, error) {
+	b.mu.Lock()
+	owner := b.owner[handle]
+	b.mu.Unlock()
+	if owner == nil {
+		return 0, os.ErrNotExist
+	}
+	return owner.Size(handle)
+}
+
+// SpaceRemaining implements StorageBackend by summing free space across all
+// paths.
+func (b *MultiPathBackend) SpaceRemaining() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var total int64
+	for _, p := range b.paths {
+		total += p.SpaceRemaining()
+	}
+	return total
+}
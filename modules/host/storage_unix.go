@@ -0,0 +1,15 @@
+// +build !windows
+
+package host
+
+import "syscall"
+
+// diskFreeSpace returns the number of bytes available to an unprivileged
+// user on the filesystem containing dir.
+func diskFreeSpace(dir string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
@@ -0,0 +1,34 @@
+// +build windows
+
+package host
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modkernel32          = syscall.NewLazyDLL("kernel32.dll")
+	procGetDiskFreeSpace = modkernel32.NewProc("GetDiskFreeSpaceExW")
+)
+
+// diskFreeSpace returns the number of bytes available to an unprivileged
+// user on the filesystem containing dir.
+func diskFreeSpace(dir string) (int64, error) {
+	path, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var freeBytesAvailable int64
+	ret, _, err := procGetDiskFreeSpace.Call(
+		uintptr(unsafe.Pointer(path)),
+		uintptr(unsafe.Pointer(&freeBytesAvailable)),
+		0,
+		0,
+	)
+	if ret == 0 {
+		return 0, err
+	}
+	return freeBytesAvailable, nil
+}